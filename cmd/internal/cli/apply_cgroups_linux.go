@@ -0,0 +1,30 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cli
+
+import (
+	clicallback "github.com/sylabs/singularity/pkg/cmdline"
+)
+
+// applyCgroupsPath holds the --apply-cgroups value, a path to a runc-flavored TOML file. The
+// individual --cpus/--memory/... flags are resolved alongside it and take precedence, since
+// they're applied after the TOML is parsed (see resolveCgroupsConfig).
+var applyCgroupsPath string
+
+// --apply-cgroups
+var applyCgroupsFlag = clicallback.Flag{
+	ID:           "applyCgroupsFlag",
+	Value:        &applyCgroupsPath,
+	DefaultValue: "",
+	Name:         "apply-cgroups",
+	Usage:        "apply cgroups resource limits from a runc-flavored TOML file",
+	EnvKeyword:   "SINGULARITY_APPLY_CGROUPS",
+}
+
+func init() {
+	clicallback.RegisterFlagForCmd(&applyCgroupsFlag, actionsCmd...)
+	clicallback.RegisterFlagForCmd(&applyCgroupsFlag, instanceStartCmd)
+}
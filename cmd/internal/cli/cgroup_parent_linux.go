@@ -0,0 +1,29 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cli
+
+import (
+	clicallback "github.com/sylabs/singularity/pkg/cmdline"
+)
+
+// cgroupParent holds the --cgroup-parent value, e.g. "my-jobs.slice". Empty means the launcher
+// should fall back to cgroups.DefaultParent for the privilege level in use.
+var cgroupParent string
+
+// --cgroup-parent
+var cgroupParentFlag = clicallback.Flag{
+	ID:           "cgroupParentFlag",
+	Value:        &cgroupParent,
+	DefaultValue: "",
+	Name:         "cgroup-parent",
+	Usage:        "systemd slice to place the container/instance's cgroup scope under, e.g. my-jobs.slice (with the cgroupfs manager, a directory of that name under /sys/fs/cgroup)",
+	EnvKeyword:   "SINGULARITY_CGROUP_PARENT",
+}
+
+func init() {
+	clicallback.RegisterFlagForCmd(&cgroupParentFlag, actionsCmd...)
+	clicallback.RegisterFlagForCmd(&cgroupParentFlag, instanceStartCmd)
+}
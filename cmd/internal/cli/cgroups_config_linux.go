@@ -0,0 +1,46 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cli
+
+import (
+	"github.com/sylabs/singularity/internal/pkg/cgroups"
+	"github.com/sylabs/singularity/pkg/sylog"
+)
+
+// resolveCgroupsConfig merges every cgroups-related flag for the current action/instance-start
+// invocation into a single Config, in ascending precedence: --apply-cgroups, then
+// --linux-resources (whichever of the two file-based flags was given - they're mutually
+// exclusive in practice, but if both are given the OCI-native one wins since it's resolved
+// second), then the individual --cpus/--cpu-shares/.../--oom-kill-disable flags, which always
+// take precedence since they're the most specific way of asking for a given limit.
+// --cgroup-parent applies regardless of which (if any) of the above was also given.
+func resolveCgroupsConfig() *cgroups.Config {
+	cfg := &cgroups.Config{}
+
+	if applyCgroupsPath != "" {
+		tomlCfg, err := cgroups.LoadConfigTOML(applyCgroupsPath)
+		if err != nil {
+			sylog.Fatalf("%s", err)
+		}
+		cfg = tomlCfg
+	}
+
+	if linuxResourcesPath != "" {
+		resources, err := cgroups.LoadLinuxResourcesJSON(linuxResourcesPath)
+		if err != nil {
+			sylog.Fatalf("%s", err)
+		}
+		cfg = cgroups.ConfigFromLinuxResources(resources)
+	}
+
+	applyResourceFlags(cfg)
+
+	if cgroupParent != "" {
+		cfg.Parent = cgroupParent
+	}
+
+	return cfg
+}
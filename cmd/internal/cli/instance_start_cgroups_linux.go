@@ -0,0 +1,48 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cli
+
+import (
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/sylabs/singularity/internal/pkg/instance"
+	"github.com/sylabs/singularity/internal/pkg/runtime/launcher/native"
+	"github.com/sylabs/singularity/pkg/sylog"
+)
+
+// placeInstanceCgroups runs after `instance start` has returned, once the instance's state file
+// (and therefore its PID) exists, to request the instance's cgroup scope - via the caller's
+// systemd-user manager when unprivileged, or directly (system bus/cgroupfs) when running as
+// root - and apply any requested cgroups limits, including a custom --cgroup-parent.
+func placeInstanceCgroups(cmd *cobra.Command, args []string) error {
+	uid := syscall.Getuid()
+
+	name := args[len(args)-1]
+	file, err := instance.Get(name, instance.SingSubDir)
+	if err != nil {
+		// instance start itself already failed/reported this - nothing more to do here.
+		return nil
+	}
+
+	cfg := resolveCgroupsConfig()
+
+	if uid == 0 {
+		if err := native.ApplyInstanceCgroupsPrivileged(file, file.Pid, cfg); err != nil {
+			sylog.Warningf("while placing instance %s in a cgroup: %s", name, err)
+		}
+		return nil
+	}
+
+	if err := native.ApplyInstanceCgroupsRootless(file, uid, file.Pid, cfg); err != nil {
+		sylog.Warningf("while placing instance %s in a cgroup: %s", name, err)
+	}
+	return nil
+}
+
+func init() {
+	instanceStartCmd.PostRunE = placeInstanceCgroups
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cli
+
+import (
+	clicallback "github.com/sylabs/singularity/pkg/cmdline"
+)
+
+// linuxResourcesPath holds the --linux-resources value, a path to a JSON file matching the OCI
+// runtime-spec LinuxResources object. Mutually exclusive with --apply-cgroups in practice, but
+// (like --apply-cgroups) resolved alongside the individual --cpus/--memory/... flags, which
+// still take precedence as they're applied last.
+var linuxResourcesPath string
+
+// --linux-resources
+var linuxResourcesFlag = clicallback.Flag{
+	ID:           "linuxResourcesFlag",
+	Value:        &linuxResourcesPath,
+	DefaultValue: "",
+	Name:         "linux-resources",
+	Usage:        "apply cgroups resource limits from a JSON file in OCI runtime-spec LinuxResources format",
+	EnvKeyword:   "SINGULARITY_LINUX_RESOURCES",
+}
+
+func init() {
+	clicallback.RegisterFlagForCmd(&linuxResourcesFlag, actionsCmd...)
+	clicallback.RegisterFlagForCmd(&linuxResourcesFlag, instanceStartCmd)
+}
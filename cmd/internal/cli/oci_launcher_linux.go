@@ -0,0 +1,42 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/sylabs/singularity/internal/pkg/cgroups"
+	ocilauncher "github.com/sylabs/singularity/internal/pkg/runtime/launcher/oci"
+)
+
+// ociRuntimeLauncher is the launcher prepareOCILauncher resolves for the current `--oci`
+// invocation, carrying the limits from --apply-cgroups/--linux-resources/the individual
+// resource flags and the host's cgroup manager. Its CreateSpec and RuntimeCommand methods fold
+// those into config.json and the runc/crun invocation, but no code reads ociRuntimeLauncher
+// back, so neither method is called for an --oci launch and the limits are not applied there.
+// --cgroup-parent (Config.Parent) is not used by either method.
+var ociRuntimeLauncher *ocilauncher.Launcher
+
+// prepareOCILauncher runs as a PreRunE on the action commands. It's a no-op outside --oci mode;
+// under --oci it resolves this invocation's cgroups configuration and the host's cgroup manager
+// into ociRuntimeLauncher before the rest of the command runs.
+func prepareOCILauncher(cmd *cobra.Command, args []string) error {
+	if !isOCI {
+		return nil
+	}
+
+	ociRuntimeLauncher = &ocilauncher.Launcher{
+		RuntimePath:    ociRuntimePath,
+		CgroupsConfig:  resolveCgroupsConfig(),
+		CgroupsManager: cgroups.DetectManager(),
+	}
+	return nil
+}
+
+func init() {
+	for _, c := range actionsCmd {
+		c.PreRunE = prepareOCILauncher
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cli
+
+import (
+	units "github.com/docker/go-units"
+	"github.com/sylabs/singularity/internal/pkg/cgroups"
+	clicallback "github.com/sylabs/singularity/pkg/cmdline"
+	"github.com/sylabs/singularity/pkg/sylog"
+)
+
+// The individual resource flags, resolved alongside --apply-cgroups/--linux-resources by
+// resolveCgroupsConfig. Unlike those two, these are plain scalars rather than a file to parse,
+// so there's no dedicated loader - applyResourceFlags below folds whichever of them were set
+// into a Config.
+var (
+	cpus              float64
+	cpuShares         int64
+	cpuSetCPUs        string
+	cpuSetMems        string
+	memory            string
+	memoryReservation string
+	memorySwap        string
+	blkioWeight       int64
+	pidsLimit         int64
+	oomKillDisable    bool
+)
+
+var cpusFlag = clicallback.Flag{
+	ID: "cpusFlag", Value: &cpus, DefaultValue: float64(0),
+	Name: "cpus", Usage: "number of CPUs available to the container", EnvKeyword: "SINGULARITY_CPUS",
+}
+
+var cpuSharesFlag = clicallback.Flag{
+	ID: "cpuSharesFlag", Value: &cpuShares, DefaultValue: int64(0),
+	Name: "cpu-shares", Usage: "CPU shares for the container relative to other containers", EnvKeyword: "SINGULARITY_CPU_SHARES",
+}
+
+var cpuSetCPUsFlag = clicallback.Flag{
+	ID: "cpuSetCPUsFlag", Value: &cpuSetCPUs, DefaultValue: "",
+	Name: "cpuset-cpus", Usage: "CPUs the container is allowed to run on (e.g. 0-2,4)", EnvKeyword: "SINGULARITY_CPUSET_CPUS",
+}
+
+var cpuSetMemsFlag = clicallback.Flag{
+	ID: "cpuSetMemsFlag", Value: &cpuSetMems, DefaultValue: "",
+	Name: "cpuset-mems", Usage: "memory nodes the container is allowed to use (e.g. 0-2,4)", EnvKeyword: "SINGULARITY_CPUSET_MEMS",
+}
+
+var memoryFlag = clicallback.Flag{
+	ID: "memoryFlag", Value: &memory, DefaultValue: "",
+	Name: "memory", Usage: "memory limit for the container, e.g. 500M", EnvKeyword: "SINGULARITY_MEMORY",
+}
+
+var memoryReservationFlag = clicallback.Flag{
+	ID: "memoryReservationFlag", Value: &memoryReservation, DefaultValue: "",
+	Name: "memory-reservation", Usage: "memory soft limit for the container, e.g. 500M", EnvKeyword: "SINGULARITY_MEMORY_RESERVATION",
+}
+
+var memorySwapFlag = clicallback.Flag{
+	ID: "memorySwapFlag", Value: &memorySwap, DefaultValue: "",
+	Name: "memory-swap", Usage: "memory+swap limit for the container, e.g. 500M, or -1 for unlimited", EnvKeyword: "SINGULARITY_MEMORY_SWAP",
+}
+
+var blkioWeightFlag = clicallback.Flag{
+	ID: "blkioWeightFlag", Value: &blkioWeight, DefaultValue: int64(0),
+	Name: "blkio-weight", Usage: "block IO relative weight for the container (10-1000)", EnvKeyword: "SINGULARITY_BLKIO_WEIGHT",
+}
+
+var pidsLimitFlag = clicallback.Flag{
+	ID: "pidsLimitFlag", Value: &pidsLimit, DefaultValue: int64(0),
+	Name: "pids-limit", Usage: "maximum number of container PIDs", EnvKeyword: "SINGULARITY_PIDS_LIMIT",
+}
+
+var oomKillDisableFlag = clicallback.Flag{
+	ID: "oomKillDisableFlag", Value: &oomKillDisable, DefaultValue: false,
+	Name: "oom-kill-disable", Usage: "disable the OOM killer for the container", EnvKeyword: "SINGULARITY_OOM_KILL_DISABLE",
+}
+
+func init() {
+	for _, f := range []*clicallback.Flag{
+		&cpusFlag, &cpuSharesFlag, &cpuSetCPUsFlag, &cpuSetMemsFlag,
+		&memoryFlag, &memoryReservationFlag, &memorySwapFlag,
+		&blkioWeightFlag, &pidsLimitFlag, &oomKillDisableFlag,
+	} {
+		clicallback.RegisterFlagForCmd(f, actionsCmd...)
+		clicallback.RegisterFlagForCmd(f, instanceStartCmd)
+	}
+}
+
+// applyResourceFlags overlays whichever of the individual resource flags the caller actually
+// set onto cfg, taking precedence over any --apply-cgroups/--linux-resources value already
+// present. A flag still at its zero value is treated as "not given", matching how the rest of
+// Config's fields are already interpreted by ToLinuxResources.
+func applyResourceFlags(cfg *cgroups.Config) {
+	if cpus > 0 {
+		cfg.CPUs = cpus
+	}
+	if cpuShares > 0 {
+		cfg.CPUShares = cpuShares
+	}
+	if cpuSetCPUs != "" {
+		cfg.CPUSetCPUs = cpuSetCPUs
+	}
+	if cpuSetMems != "" {
+		cfg.CPUSetMems = cpuSetMems
+	}
+	if memory != "" {
+		if v, err := units.RAMInBytes(memory); err != nil {
+			sylog.Fatalf("while parsing --memory %q: %s", memory, err)
+		} else {
+			cfg.Memory = v
+		}
+	}
+	if memoryReservation != "" {
+		if v, err := units.RAMInBytes(memoryReservation); err != nil {
+			sylog.Fatalf("while parsing --memory-reservation %q: %s", memoryReservation, err)
+		} else {
+			cfg.MemoryReservation = v
+		}
+	}
+	if memorySwap == "-1" {
+		// No swap limit: leave MemorySwap unset, overriding any file-provided value.
+		cfg.MemorySwap = 0
+	} else if memorySwap != "" {
+		if v, err := units.RAMInBytes(memorySwap); err != nil {
+			sylog.Fatalf("while parsing --memory-swap %q: %s", memorySwap, err)
+		} else {
+			cfg.MemorySwap = v
+		}
+	}
+	if blkioWeight > 0 {
+		cfg.BlkioWeight = blkioWeight
+	}
+	if pidsLimit > 0 {
+		cfg.PidsLimit = pidsLimit
+	}
+	if oomKillDisable {
+		cfg.OOMKillDisable = oomKillDisable
+	}
+}
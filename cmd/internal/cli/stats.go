@@ -0,0 +1,165 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sylabs/singularity/internal/pkg/cgroups"
+	"github.com/sylabs/singularity/internal/pkg/instance"
+	clicallback "github.com/sylabs/singularity/pkg/cmdline"
+	"github.com/sylabs/singularity/pkg/sylog"
+)
+
+var (
+	statsFormat   string
+	statsNoStream bool
+)
+
+// --format table|json
+var statsFormatFlag = clicallback.Flag{
+	ID:           "statsFormatFlag",
+	Value:        &statsFormat,
+	DefaultValue: "table",
+	Name:         "format",
+	Usage:        "output format, one of: table, json",
+	EnvKeyword:   "SINGULARITY_STATS_FORMAT",
+}
+
+// --no-stream
+var statsNoStreamFlag = clicallback.Flag{
+	ID:           "statsNoStreamFlag",
+	Value:        &statsNoStream,
+	DefaultValue: false,
+	Name:         "no-stream",
+	Usage:        "disable streaming stats and only pull the first result",
+	EnvKeyword:   "SINGULARITY_STATS_NO_STREAM",
+}
+
+func init() {
+	clicallback.RegisterFlagForCmd(&statsFormatFlag, instanceStatsCmd, statsCmd)
+	clicallback.RegisterFlagForCmd(&statsNoStreamFlag, instanceStatsCmd, statsCmd)
+
+	instanceCmd.AddCommand(instanceStatsCmd)
+	singularityCmd.AddCommand(statsCmd)
+}
+
+// statEntry is the live usage snapshot for a single container or instance, in the shape
+// reported by both `singularity stats` and `singularity instance stats`.
+type statEntry struct {
+	Name         string  `json:"name"`
+	CPU          cpuStat `json:"cpu"`
+	MemoryLimit  uint64  `json:"memory.limit"`
+	MemoryUsage  uint64  `json:"memory.usage"`
+	MemoryPeak   uint64  `json:"memory.peak"`
+	PidsCurrent  uint64  `json:"pids.current"`
+	PidsMax      uint64  `json:"pids.max"`
+	IOReadBytes  uint64  `json:"io.read_bytes"`
+	IOWriteBytes uint64  `json:"io.write_bytes"`
+}
+
+type cpuStat struct {
+	UsageUsec uint64 `json:"usage_usec"`
+}
+
+func statEntryFromStats(name string, s *cgroups.Stats) statEntry {
+	return statEntry{
+		Name:         name,
+		CPU:          cpuStat{UsageUsec: s.CPUUsageUsec},
+		MemoryLimit:  s.MemLimit,
+		MemoryUsage:  s.MemCurrent,
+		MemoryPeak:   s.MemPeak,
+		PidsCurrent:  s.PidsCurrent,
+		PidsMax:      s.PidsMax,
+		IOReadBytes:  s.IOReadBytes,
+		IOWriteBytes: s.IOWriteBytes,
+	}
+}
+
+func printStats(entries []statEntry) error {
+	switch statsFormat {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+	default:
+		fmt.Printf("%-24s %12s %12s %12s %10s\n", "NAME", "CPU (usec)", "MEM USAGE", "MEM LIMIT", "PIDS")
+		for _, e := range entries {
+			fmt.Printf("%-24s %12d %12d %12d %10d\n", e.Name, e.CPU.UsageUsec, e.MemoryUsage, e.MemoryLimit, e.PidsCurrent)
+		}
+	}
+	return nil
+}
+
+func runStats(name string, paths map[string]string) error {
+	for {
+		s, err := cgroups.ReadStats(paths)
+		if err != nil {
+			return fmt.Errorf("while reading cgroup stats for %s: %w", name, err)
+		}
+		if err := printStats([]statEntry{statEntryFromStats(name, s)}); err != nil {
+			return err
+		}
+		if statsNoStream {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// instanceStatsCmd implements `singularity instance stats <instance>`.
+var instanceStatsCmd = &cobra.Command{
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Use:                   "stats [stats options...] <instance name>",
+	Short:                 "Report live resource usage for a running instance",
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		file, err := instance.Get(name, instance.SingSubDir)
+		if err != nil {
+			sylog.Fatalf("while looking up instance %s: %s", name, err)
+		}
+		if file.CgroupPath == "" {
+			sylog.Fatalf("instance %s was not started with cgroups applied - nothing to report", name)
+		}
+		if err := runStats(name, map[string]string{"": file.CgroupPath}); err != nil {
+			sylog.Fatalf("%s", err)
+		}
+	},
+}
+
+// statsCmd implements `singularity stats <PID>`, reporting usage for a container process
+// started in the foreground (via `exec`/`run`/`shell`) rather than as a background instance.
+// There's no state file to read a cgroup path back from in that case, so the cgroup is
+// discovered directly from /proc/<pid>/cgroup instead.
+var statsCmd = &cobra.Command{
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Use:                   "stats [stats options...] <PID>",
+	Short:                 "Report live resource usage for a running container",
+	Run: func(cmd *cobra.Command, args []string) {
+		pid, err := strconv.Atoi(args[0])
+		if err != nil {
+			sylog.Fatalf("expected a PID, got %q: %s", args[0], err)
+		}
+
+		path, err := cgroups.PathForPID(pid)
+		if err != nil {
+			sylog.Fatalf("while locating cgroup for pid %d: %s", pid, err)
+		}
+		if err := runStats(args[0], path); err != nil {
+			sylog.Fatalf("%s", err)
+		}
+	},
+}
@@ -39,6 +39,10 @@ func randomName(t *testing.T) string {
 
 type ctx struct {
 	env e2e.TestEnv
+	// oci runs the table-driven cases through `--oci` mode instead of the native launcher. The
+	// cgroup path probes below derive their target path from /proc/self/cgroup at runtime, so
+	// they adapt automatically to the runtime-chosen scope name runc/crun create under --oci.
+	oci bool
 }
 
 // moved from INSTANCE suite, as testing with systemd cgroup manager requires
@@ -56,6 +60,11 @@ func (c *ctx) instanceApply(t *testing.T, profile e2e.Profile) {
 		execErrorOut   string
 		rootfull       bool
 		rootless       bool
+		// requireDelegation skips the rootless case unless the named controller has been
+		// delegated to the user's systemd-user cgroup (see /sys/fs/cgroup/.../cgroup.controllers).
+		// Controllers that aren't delegated (commonly devices) are skipped with a warning by the
+		// launcher rather than failing, so there's nothing to assert without delegation.
+		requireDelegation string
 	}{
 		{
 			name:           "nonexistent toml",
@@ -95,14 +104,32 @@ func (c *ctx) instanceApply(t *testing.T, profile e2e.Profile) {
 			rootless:       true,
 		},
 		{
-			name:           "device deny",
-			createArgs:     []string{"--apply-cgroups", "testdata/cgroups/deny_device.toml", c.env.ImagePath},
+			name:       "linux-resources memory limit",
+			createArgs: []string{"--linux-resources", "testdata/cgroups/memory_limit.json", c.env.ImagePath},
+			// Same as the --apply-cgroups memory limit case: CLI error, not the starter's 137.
+			startErrorCode: 255,
+			rootfull:       true,
+			rootless:       true,
+		},
+		{
+			name:           "linux-resources cpu success",
+			createArgs:     []string{"--linux-resources", "testdata/cgroups/cpu_success.json", c.env.ImagePath},
 			startErrorCode: 0,
-			execArgs:       []string{"cat", "/dev/null"},
-			execErrorCode:  1,
-			execErrorOut:   "Operation not permitted",
+			execArgs:       []string{"/bin/true"},
+			execErrorCode:  0,
 			rootfull:       true,
-			rootless:       false,
+			rootless:       true,
+		},
+		{
+			name:              "device deny",
+			createArgs:        []string{"--apply-cgroups", "testdata/cgroups/deny_device.toml", c.env.ImagePath},
+			startErrorCode:    0,
+			execArgs:          []string{"cat", "/dev/null"},
+			execErrorCode:     1,
+			execErrorOut:      "Operation not permitted",
+			rootfull:          true,
+			rootless:          true,
+			requireDelegation: "devices",
 		},
 	}
 
@@ -113,6 +140,9 @@ func (c *ctx) instanceApply(t *testing.T, profile e2e.Profile) {
 		if !profile.Privileged() && !tt.rootless {
 			t.Skip()
 		}
+		if !profile.Privileged() && tt.requireDelegation != "" {
+			require.CgroupsV2Delegated(t, tt.requireDelegation)
+		}
 
 		createExitFunc := []e2e.SingularityCmdResultOp{}
 		if tt.startErrorOut != "" {
@@ -164,25 +194,15 @@ func (c *ctx) instanceApplyRoot(t *testing.T) {
 	c.instanceApply(t, e2e.RootProfile)
 }
 
-// TODO - when instance support for rootless cgroups is ready, this
-// should instead call instanceApply over the user profiles.
 func (c *ctx) instanceApplyRootless(t *testing.T) {
-	e2e.EnsureImage(t, c.env)
-	// pick up a random name
-	instanceName := randomName(t)
-
-	c.env.RunSingularity(
-		t,
-		e2e.WithProfile(e2e.UserProfile),
-		e2e.WithRootlessEnv(),
-		e2e.WithCommand("instance start"),
-		e2e.WithArgs("--apply-cgroups", "testdata/cgroups/memory_limit.toml", c.env.ImagePath, instanceName),
-		e2e.ExpectExit(255,
-			e2e.ExpectError(e2e.ContainMatch, "Instances do not currently support rootless cgroups")),
-	)
+	for _, profile := range []e2e.Profile{e2e.UserProfile, e2e.UserNamespaceProfile, e2e.FakerootProfile} {
+		t.Run(profile.String(), func(t *testing.T) {
+			c.instanceApply(t, profile)
+		})
+	}
 }
 
-func (c *ctx) actionApply(t *testing.T, profile e2e.Profile) {
+func (c *ctx) actionApply(t *testing.T, profile e2e.Profile, imageRef string) {
 	e2e.EnsureImage(t, c.env)
 
 	tests := []struct {
@@ -192,10 +212,12 @@ func (c *ctx) actionApply(t *testing.T, profile e2e.Profile) {
 		expectErrorOut  string
 		rootfull        bool
 		rootless        bool
+		skipOCI         bool
+		onlyOCI         bool
 	}{
 		{
 			name:            "nonexistent toml",
-			args:            []string{"--apply-cgroups", "testdata/cgroups/doesnotexist.toml", c.env.ImagePath, "/bin/sleep", "5"},
+			args:            []string{"--apply-cgroups", "testdata/cgroups/doesnotexist.toml", imageRef, "/bin/sleep", "5"},
 			expectErrorCode: 255,
 			expectErrorOut:  "no such file or directory",
 			rootfull:        true,
@@ -203,7 +225,7 @@ func (c *ctx) actionApply(t *testing.T, profile e2e.Profile) {
 		},
 		{
 			name:            "invalid toml",
-			args:            []string{"--apply-cgroups", "testdata/cgroups/invalid.toml", c.env.ImagePath, "/bin/sleep", "5"},
+			args:            []string{"--apply-cgroups", "testdata/cgroups/invalid.toml", imageRef, "/bin/sleep", "5"},
 			expectErrorCode: 255,
 			expectErrorOut:  "parsing error",
 			rootfull:        true,
@@ -211,14 +233,14 @@ func (c *ctx) actionApply(t *testing.T, profile e2e.Profile) {
 		},
 		{
 			name:            "memory limit",
-			args:            []string{"--apply-cgroups", "testdata/cgroups/memory_limit.toml", c.env.ImagePath, "/bin/sleep", "5"},
+			args:            []string{"--apply-cgroups", "testdata/cgroups/memory_limit.toml", imageRef, "/bin/sleep", "5"},
 			expectErrorCode: 137,
 			rootfull:        true,
 			rootless:        true,
 		},
 		{
 			name:            "cpu success",
-			args:            []string{"--apply-cgroups", "testdata/cgroups/cpu_success.toml", c.env.ImagePath, "/bin/true"},
+			args:            []string{"--apply-cgroups", "testdata/cgroups/cpu_success.toml", imageRef, "/bin/true"},
 			expectErrorCode: 0,
 			rootfull:        true,
 			// This currently fails in the e2e scenario due to the way we are using a mount namespace.
@@ -226,10 +248,27 @@ func (c *ctx) actionApply(t *testing.T, profile e2e.Profile) {
 			// Reason is believed to be: https://github.com/opencontainers/runc/issues/3026
 			rootless: false,
 		},
+		{
+			name:            "linux-resources memory limit",
+			args:            []string{"--linux-resources", "testdata/cgroups/memory_limit.json", imageRef, "/bin/sleep", "5"},
+			expectErrorCode: 137,
+			rootfull:        true,
+			rootless:        true,
+		},
+		{
+			name:            "linux-resources cpu success",
+			args:            []string{"--linux-resources", "testdata/cgroups/cpu_success.json", imageRef, "/bin/true"},
+			expectErrorCode: 0,
+			rootfull:        true,
+			// See the equivalent --apply-cgroups cpu success case above.
+			rootless: false,
+		},
 		// Device limits are properly applied only in rootful mode. Rootless will ignore them with a warning.
+		// Under --oci, runc/crun enforce device cgroups the same way regardless of caller privilege, so the
+		// "ignored" rootless case doesn't apply there.
 		{
 			name:            "device deny",
-			args:            []string{"--apply-cgroups", "testdata/cgroups/deny_device.toml", c.env.ImagePath, "cat", "/dev/null"},
+			args:            []string{"--apply-cgroups", "testdata/cgroups/deny_device.toml", imageRef, "cat", "/dev/null"},
 			expectErrorCode: 1,
 			expectErrorOut:  "Operation not permitted",
 			rootfull:        true,
@@ -237,11 +276,12 @@ func (c *ctx) actionApply(t *testing.T, profile e2e.Profile) {
 		},
 		{
 			name:            "device ignored",
-			args:            []string{"--apply-cgroups", "testdata/cgroups/deny_device.toml", c.env.ImagePath, "cat", "/dev/null"},
+			args:            []string{"--apply-cgroups", "testdata/cgroups/deny_device.toml", imageRef, "cat", "/dev/null"},
 			expectErrorCode: 0,
 			expectErrorOut:  "Operation not permitted",
 			rootfull:        false,
 			rootless:        true,
+			skipOCI:         true,
 		},
 	}
 
@@ -252,47 +292,93 @@ func (c *ctx) actionApply(t *testing.T, profile e2e.Profile) {
 		if !profile.Privileged() && !tt.rootless {
 			t.Skip()
 		}
+		if c.oci && tt.skipOCI {
+			t.Skip()
+		}
+		if !c.oci && tt.onlyOCI {
+			t.Skip()
+		}
 		exitFunc := []e2e.SingularityCmdResultOp{}
 		if tt.expectErrorOut != "" {
 			exitFunc = []e2e.SingularityCmdResultOp{e2e.ExpectError(e2e.ContainMatch, tt.expectErrorOut)}
 		}
+		args := tt.args
+		if c.oci {
+			args = append([]string{"--oci"}, args...)
+		}
 		c.env.RunSingularity(
 			t,
 			e2e.AsSubtest(tt.name),
 			e2e.WithProfile(profile),
 			e2e.WithCommand("exec"),
-			e2e.WithArgs(tt.args...),
+			e2e.WithArgs(args...),
 			e2e.ExpectExit(tt.expectErrorCode, exitFunc...),
 		)
 	}
 }
 
 func (c *ctx) actionApplyRoot(t *testing.T) {
-	c.actionApply(t, e2e.RootProfile)
+	c.actionApply(t, e2e.RootProfile, c.env.ImagePath)
 }
 
 func (c *ctx) actionApplyRootless(t *testing.T) {
 	for _, profile := range []e2e.Profile{e2e.UserProfile, e2e.UserNamespaceProfile, e2e.FakerootProfile} {
 		t.Run(profile.String(), func(t *testing.T) {
-			c.actionApply(t, profile)
+			c.actionApply(t, profile, c.env.ImagePath)
 		})
 	}
 }
 
+// actionApplyOCIRoot and actionApplyOCIRootless re-run the same table against --oci mode. The OCI
+// launcher shares the cgroup limit resolution code with the native launcher, but drives it through
+// runc/crun rather than programming cgroupfs directly, so it's covered as its own ctx.oci variant
+// rather than folded into every existing rootfull/rootless case above.
+func (c *ctx) actionApplyOCIRoot(t *testing.T) {
+	oc := &ctx{env: c.env, oci: true}
+	oc.actionApply(t, e2e.OCIRootProfile, c.env.ImagePath)
+}
+
+func (c *ctx) actionApplyOCIRootless(t *testing.T) {
+	oc := &ctx{env: c.env, oci: true}
+	oc.actionApply(t, e2e.OCIUserProfile, c.env.ImagePath)
+}
+
+// hierarchy identifies which cgroup hierarchy (or both) an expectation applies to.
+type hierarchy int
+
+const (
+	hierarchyV1 hierarchy = iota
+	hierarchyV2
+	hierarchyBoth
+)
+
+// expectation is one cgroupfs file this test case expects the container's cgroup to contain,
+// with the value it should hold. A single actionFlagTest can carry several of these, e.g.
+// --cpus should check both cpu.max and the presence of a period on v2.
+type expectation struct {
+	Hierarchy hierarchy
+	// Controller is the v1 controller subdirectory (ignored on v2, where the hierarchy is
+	// unified and the cgroup path alone is enough to locate the resource file).
+	Controller string
+	Resource   string
+	Match      string
+	// Delegation is the v2 controller that must be delegated to test this expectation
+	// rootless. Ignored on v1 and when running as root.
+	Delegation string
+}
+
+func (e expectation) appliesTo(h hierarchy) bool {
+	return e.Hierarchy == hierarchyBoth || e.Hierarchy == h
+}
+
 type actionFlagTest struct {
 	name            string
 	args            []string
 	expectErrorCode int
-	// cgroupsV1 - cgroupfs controller/resource to check, and content we expect to see
-	controllerV1 string
-	resourceV1   string
-	expectV1     string
-	// cgroupsV2 - delegation required when rootless
-	delegationV2 string
-	// cgroupsV2 - resource to check, and content we expect to see
-	resourceV2 string
-	expectV2   string
-	skipV2     bool
+	expectations    []expectation
+	// skipOCI skips this case under --oci mode, e.g. where a controller's semantics differ
+	// between the native runtime and runc/crun.
+	skipOCI bool
 }
 
 func (c *ctx) actionFlags(t *testing.T, profile e2e.Profile) {
@@ -302,201 +388,333 @@ func (c *ctx) actionFlags(t *testing.T, profile e2e.Profile) {
 			name:            "blkio-weight",
 			args:            []string{"--blkio-weight", "50"},
 			expectErrorCode: 0,
-			controllerV1:    "blkio",
-			// This is the new path. Older kernels may have only `blkio.weight`
-			resourceV1:   "blkio.bfq.weight",
-			expectV1:     "50",
-			delegationV2: "io",
-			resourceV2:   "io.bfq.weight",
-			expectV2:     "default 50",
+			expectations: []expectation{
+				// This is the new path. Older kernels may have only `blkio.weight`
+				{Hierarchy: hierarchyV1, Controller: "blkio", Resource: "blkio.bfq.weight", Match: "50"},
+				{Hierarchy: hierarchyV2, Delegation: "io", Resource: "io.bfq.weight", Match: "default 50"},
+			},
 		},
 		{
 			name:            "cpus",
 			args:            []string{"--cpus", "0.5"},
 			expectErrorCode: 0,
-			// 0.5 cpus = quota of 50000 with default period 100000
-			controllerV1: "cpu",
-			resourceV1:   "cpu.cfs_quota_us",
-			expectV1:     "50000",
-			delegationV2: "cpu",
-			resourceV2:   "cpu.max",
-			expectV2:     "50000 100000",
+			expectations: []expectation{
+				// 0.5 cpus = quota of 50000 with default period 100000
+				{Hierarchy: hierarchyV1, Controller: "cpu", Resource: "cpu.cfs_quota_us", Match: "50000"},
+				{Hierarchy: hierarchyV1, Controller: "cpu", Resource: "cpu.cfs_period_us", Match: "100000"},
+				{Hierarchy: hierarchyV2, Delegation: "cpu", Resource: "cpu.max", Match: "50000 100000"},
+			},
 		},
 		{
 			name:            "cpu-shares",
 			args:            []string{"--cpu-shares", "123"},
 			expectErrorCode: 0,
-			controllerV1:    "cpu",
-			resourceV1:      "cpu.shares",
-			expectV1:        "123",
-			// Cgroups v2 has a conversion from shares to weight
-			// weight = (1 + ((cpuShares-2)*9999)/262142)
-			delegationV2: "cpu",
-			resourceV2:   "cpu.weight",
-			expectV2:     "5",
+			expectations: []expectation{
+				{Hierarchy: hierarchyV1, Controller: "cpu", Resource: "cpu.shares", Match: "123"},
+				// Cgroups v2 has a conversion from shares to weight
+				// weight = (1 + ((cpuShares-2)*9999)/262142)
+				{Hierarchy: hierarchyV2, Delegation: "cpu", Resource: "cpu.weight", Match: "5"},
+			},
 		},
 		{
 			name:            "cpuset-cpus",
 			args:            []string{"--cpuset-cpus", "0", "--cpuset-mems", "0"},
 			expectErrorCode: 0,
-			controllerV1:    "cpuset",
-			resourceV1:      "cpuset.cpus",
-			expectV1:        "0",
-			delegationV2:    "cpuset",
-			resourceV2:      "cpuset.cpus",
-			expectV2:        "0",
+			expectations: []expectation{
+				{Hierarchy: hierarchyV1, Controller: "cpuset", Resource: "cpuset.cpus", Match: "0"},
+				{Hierarchy: hierarchyV2, Delegation: "cpuset", Resource: "cpuset.cpus", Match: "0"},
+			},
 		},
 		{
 			name:            "cpuset-mems",
 			args:            []string{"--cpuset-cpus", "0", "--cpuset-mems", "0"},
 			expectErrorCode: 0,
-			controllerV1:    "cpuset",
-			resourceV1:      "cpuset.mems",
-			expectV1:        "0",
-			delegationV2:    "cpuset",
-			resourceV2:      "cpuset.mems",
-			expectV2:        "0",
+			expectations: []expectation{
+				{Hierarchy: hierarchyV1, Controller: "cpuset", Resource: "cpuset.mems", Match: "0"},
+				{Hierarchy: hierarchyV2, Delegation: "cpuset", Resource: "cpuset.mems", Match: "0"},
+			},
 		},
 		{
 			name:            "memory",
 			args:            []string{"--memory", "500M"},
 			expectErrorCode: 0,
-			controllerV1:    "memory",
-			resourceV1:      "memory.limit_in_bytes",
-			expectV1:        "524288000",
-			delegationV2:    "memory",
-			resourceV2:      "memory.max",
-			expectV2:        "524288000",
+			expectations: []expectation{
+				{Hierarchy: hierarchyV1, Controller: "memory", Resource: "memory.limit_in_bytes", Match: "524288000"},
+				{Hierarchy: hierarchyV2, Delegation: "memory", Resource: "memory.max", Match: "524288000"},
+			},
 		},
 		{
 			name:            "memory-reservation",
 			args:            []string{"--memory-reservation", "500M"},
 			expectErrorCode: 0,
-			controllerV1:    "memory",
-			resourceV1:      "memory.soft_limit_in_bytes",
-			expectV1:        "524288000",
-			delegationV2:    "memory",
-			resourceV2:      "memory.low",
-			expectV2:        "524288000",
+			expectations: []expectation{
+				{Hierarchy: hierarchyV1, Controller: "memory", Resource: "memory.soft_limit_in_bytes", Match: "524288000"},
+				{Hierarchy: hierarchyV2, Delegation: "memory", Resource: "memory.low", Match: "524288000"},
+			},
 		},
 		{
 			// The CLI memory-swap value is v1 memory + swap... so this means 250M of swap
 			name:            "memory-swap",
 			args:            []string{"--memory-swap", "500M", "--memory", "250M"},
 			expectErrorCode: 0,
-			controllerV1:    "memory",
-			resourceV1:      "memory.memsw.limit_in_bytes",
-			// V1 shows the 500M combined
-			expectV1: "524288000",
-			// V2 treats the mem & swap separately... shows only 250M of swap (500M memory-swap - 250M memory)
-			delegationV2: "memory",
-			resourceV2:   "memory.swap.max",
-			expectV2:     "262144000",
+			expectations: []expectation{
+				// V1 shows the 500M combined
+				{Hierarchy: hierarchyV1, Controller: "memory", Resource: "memory.memsw.limit_in_bytes", Match: "524288000"},
+				// V2 treats the mem & swap separately... shows only 250M of swap (500M memory-swap - 250M memory)
+				{Hierarchy: hierarchyV2, Delegation: "memory", Resource: "memory.swap.max", Match: "262144000"},
+			},
+		},
+		{
+			name:            "memory-swappiness",
+			args:            []string{"--memory-swappiness", "60"},
+			expectErrorCode: 0,
+			expectations: []expectation{
+				{Hierarchy: hierarchyV1, Controller: "memory", Resource: "memory.swappiness", Match: "60"},
+				// v2 has no equivalent knob - swappiness is a v1-only tunable.
+			},
 		},
 		{
 			name:            "oom-kill-disable",
 			args:            []string{"--oom-kill-disable"},
 			expectErrorCode: 0,
-			controllerV1:    "memory",
-			resourceV1:      "memory.oom_control",
-			expectV1:        "oom_kill_disable 1",
-			// v2 relies on oom_score_adj on /proc/pid instead
-			skipV2: true,
+			expectations: []expectation{
+				{Hierarchy: hierarchyV1, Controller: "memory", Resource: "memory.oom_control", Match: "oom_kill_disable 1"},
+				// v2 relies on oom_score_adj on /proc/pid instead
+			},
 		},
 		{
 			name:            "pids-limit",
 			args:            []string{"--pids-limit", "123"},
 			expectErrorCode: 0,
-			controllerV1:    "pids",
-			resourceV1:      "pids.max",
-			expectV1:        "123",
-			delegationV2:    "pids",
-			resourceV2:      "pids.max",
-			expectV2:        "123",
+			expectations: []expectation{
+				{Hierarchy: hierarchyV1, Controller: "pids", Resource: "pids.max", Match: "123"},
+				{Hierarchy: hierarchyV2, Delegation: "pids", Resource: "pids.max", Match: "123"},
+			},
+		},
+		{
+			name:            "cpu-rt-period",
+			args:            []string{"--cpu-rt-period", "900000"},
+			expectErrorCode: 0,
+			expectations: []expectation{
+				{Hierarchy: hierarchyV1, Controller: "cpu", Resource: "cpu.rt_period_us", Match: "900000"},
+				// v2 has no realtime scheduling knobs.
+			},
+		},
+		{
+			name:            "cpu-rt-runtime",
+			args:            []string{"--cpu-rt-period", "900000", "--cpu-rt-runtime", "450000"},
+			expectErrorCode: 0,
+			expectations: []expectation{
+				{Hierarchy: hierarchyV1, Controller: "cpu", Resource: "cpu.rt_runtime_us", Match: "450000"},
+			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if cgroups.IsCgroup2UnifiedMode() {
-				c.actionFlagV2(t, tt, profile)
-				return
+			if c.oci && tt.skipOCI {
+				t.Skip()
 			}
-			c.actionFlagV1(t, tt, profile)
+			c.runActionFlag(t, tt, profile)
 		})
 	}
 }
 
-func (c *ctx) actionFlagV1(t *testing.T, tt actionFlagTest, profile e2e.Profile) {
-	// Don't try to test a resource that doesn't exist in our caller cgroup.
-	// E.g. some systems don't have memory.memswp, and might not have blkio.bfq
-	require.CgroupsResourceExists(t, tt.controllerV1, tt.resourceV1)
+// runActionFlag runs tt against whichever hierarchy (v1 or v2) the host has, checking every
+// expectation applicable to that hierarchy. Cases with no applicable expectation at all (e.g.
+// memory-swappiness on a v2-only host) are skipped rather than silently passing.
+func (c *ctx) runActionFlag(t *testing.T, tt actionFlagTest, profile e2e.Profile) {
+	h := hierarchyV1
+	if cgroups.IsCgroup2UnifiedMode() {
+		h = hierarchyV2
+	}
+
+	applicable := []expectation{}
+	for _, e := range tt.expectations {
+		if e.appliesTo(h) {
+			applicable = append(applicable, e)
+		}
+	}
+	if len(applicable) == 0 {
+		t.Skip()
+	}
+
+	for _, e := range applicable {
+		name := e.Resource
+		t.Run(name, func(t *testing.T) {
+			if h == hierarchyV1 {
+				// Don't try to test a resource that doesn't exist in our caller cgroup.
+				// E.g. some systems don't have memory.memswp, and might not have blkio.bfq
+				require.CgroupsResourceExists(t, e.Controller, e.Resource)
+			} else if !profile.Privileged() && e.Delegation != "" {
+				// In rootless mode, can only test subsystems that have been delegated
+				require.CgroupsV2Delegated(t, e.Delegation)
+			}
+
+			var shellCmd string
+			if h == hierarchyV1 {
+				// /proc/self/cgroup is : delimited; controller is the 2nd field, cgroup path
+				// relative to root cgroup mount is the 3rd field.
+				shellCmd = fmt.Sprintf("cat /sys/fs/cgroup/%s$(cat /proc/self/cgroup | grep '[,:]%s[,:]' | cut -d ':' -f 3)/%s", e.Controller, e.Controller, e.Resource)
+			} else {
+				// For V2 the controller is null (field 2), at index 0 (field 1).
+				shellCmd = fmt.Sprintf("cat /sys/fs/cgroup$(cat /proc/self/cgroup | grep '^0::' | cut -d ':' -f 3)/%s", e.Resource)
+			}
+
+			exitFunc := []e2e.SingularityCmdResultOp{}
+			if e.Match != "" {
+				exitFunc = []e2e.SingularityCmdResultOp{e2e.ExpectOutput(e2e.ContainMatch, e.Match)}
+			}
 
-	// Use shell in the container to find container cgroup and cat the value for the tested controller & resource.
-	// /proc/self/cgroup is : delimited
-	// controller is the 2nd field in `/proc/self/cgroup`
-	// cgroup path relative to root cgroup mount is the 3rd field in `/proc/self/cgroup`
-	shellCmd := fmt.Sprintf("cat /sys/fs/cgroup/%s$(cat /proc/self/cgroup | grep '[,:]%s[,:]' | cut -d ':' -f 3)/%s", tt.controllerV1, tt.controllerV1, tt.resourceV1)
+			args := tt.args
+			if c.oci {
+				args = append([]string{"--oci"}, args...)
+			}
+			args = append(args, "-B", "/sys/fs/cgroup", c.env.ImagePath, "/bin/sh", "-c", shellCmd)
+
+			c.env.RunSingularity(
+				t,
+				e2e.WithProfile(profile),
+				e2e.WithCommand("exec"),
+				e2e.WithArgs(args...),
+				e2e.ExpectExit(tt.expectErrorCode, exitFunc...),
+			)
+		})
+	}
+}
+
+func (c *ctx) actionFlagsRoot(t *testing.T) {
+	c.actionFlags(t, e2e.RootProfile)
+}
 
-	exitFunc := []e2e.SingularityCmdResultOp{}
-	if tt.expectV1 != "" {
-		exitFunc = []e2e.SingularityCmdResultOp{e2e.ExpectOutput(e2e.ContainMatch, tt.expectV1)}
+func (c *ctx) actionFlagsRootless(t *testing.T) {
+	for _, profile := range []e2e.Profile{e2e.UserProfile, e2e.UserNamespaceProfile, e2e.FakerootProfile} {
+		t.Run(profile.String(), func(t *testing.T) {
+			c.actionFlags(t, profile)
+		})
 	}
+}
+
+func (c *ctx) actionFlagsOCIRoot(t *testing.T) {
+	oc := &ctx{env: c.env, oci: true}
+	oc.actionFlags(t, e2e.OCIRootProfile)
+}
 
-	args := tt.args
-	args = append(args, "-B", "/sys/fs/cgroup", c.env.ImagePath, "/bin/sh", "-c", shellCmd)
+func (c *ctx) actionFlagsOCIRootless(t *testing.T) {
+	oc := &ctx{env: c.env, oci: true}
+	oc.actionFlags(t, e2e.OCIUserProfile)
+}
+
+// instanceStats starts an instance under a known memory limit, runs a short CPU/memory workload
+// inside it, then asserts that `instance stats --no-stream --format json` reports plausible,
+// non-zero usage and the configured memory limit.
+func (c *ctx) instanceStats(t *testing.T, profile e2e.Profile) {
+	e2e.EnsureImage(t, c.env)
+
+	instanceName := randomName(t)
 
 	c.env.RunSingularity(
 		t,
+		e2e.AsSubtest("start"),
+		e2e.WithProfile(profile),
+		e2e.WithCommand("instance start"),
+		e2e.WithArgs("--apply-cgroups", "testdata/cgroups/memory_limit.toml", c.env.ImagePath, instanceName),
+		e2e.ExpectExit(0),
+	)
+	defer c.env.RunSingularity(
+		t,
+		e2e.AsSubtest("stop"),
+		e2e.WithProfile(profile),
+		e2e.WithCommand("instance stop"),
+		e2e.WithArgs(instanceName),
+		e2e.ExpectExit(0),
+	)
+
+	// Burn a little CPU and touch some memory so cpu.stat/memory.current have moved off zero
+	// by the time we read stats below.
+	c.env.RunSingularity(
+		t,
+		e2e.AsSubtest("workload"),
 		e2e.WithProfile(profile),
 		e2e.WithCommand("exec"),
-		e2e.WithArgs(args...),
-		e2e.ExpectExit(tt.expectErrorCode, exitFunc...),
+		e2e.WithArgs(fmt.Sprintf("instance://%s", instanceName), "/bin/sh", "-c",
+			"dd if=/dev/zero of=/dev/null bs=1M count=256"),
+		e2e.ExpectExit(0),
+	)
+
+	c.env.RunSingularity(
+		t,
+		e2e.AsSubtest("stats"),
+		e2e.WithProfile(profile),
+		e2e.WithCommand("instance stats"),
+		e2e.WithArgs("--no-stream", "--format", "json", instanceName),
+		e2e.ExpectExit(0,
+			e2e.ExpectOutput(e2e.RegexMatch, `"cpu":\{"usage_usec":[1-9]`),
+			e2e.ExpectOutput(e2e.ContainMatch, `"memory.limit":134217728`),
+		),
 	)
 }
 
-func (c *ctx) actionFlagV2(t *testing.T, tt actionFlagTest, profile e2e.Profile) {
-	if tt.skipV2 {
-		t.Skip()
-	}
-	// In rootless mode, can only test subsystems that have been delegated
-	if !profile.Privileged() {
-		require.CgroupsV2Delegated(t, tt.delegationV2)
-	}
+func (c *ctx) instanceStatsRoot(t *testing.T) {
+	c.instanceStats(t, e2e.RootProfile)
+}
 
-	exitFunc := []e2e.SingularityCmdResultOp{}
-	if tt.expectV2 != "" {
-		exitFunc = []e2e.SingularityCmdResultOp{e2e.ExpectOutput(e2e.ContainMatch, tt.expectV2)}
-	}
+func (c *ctx) instanceStatsRootless(t *testing.T) {
+	c.instanceStats(t, e2e.UserProfile)
+}
 
-	// Use shell in the container to find container cgroup and cat the value for the tested controller & resource.
-	// /proc/self/cgroup is : delimited
-	// For V2 the controller is null (field 2), at index 0 (field 1)
-	// cgroup path relative to root cgroup mount is the 3rd field in `/proc/self/cgroup`
-	shellCmd := fmt.Sprintf("cat /sys/fs/cgroup$(cat /proc/self/cgroup | grep '^0::' | cut -d ':' -f 3)/%s", tt.resourceV2)
+// cgroupParent starts an instance under a custom --cgroup-parent slice and asserts both that
+// systemd reports the resulting scope as active, and that /proc/<pid>/cgroup shows the expected
+// "<parent>.slice/singularity-<id>.scope" suffix.
+func (c *ctx) cgroupParent(t *testing.T, profile e2e.Profile) {
+	e2e.EnsureImage(t, c.env)
+
+	instanceName := randomName(t)
+	parent := "e2e-test.slice"
+	scope := fmt.Sprintf("singularity-%s.scope", instanceName)
+
+	c.env.RunSingularity(
+		t,
+		e2e.AsSubtest("start"),
+		e2e.WithProfile(profile),
+		e2e.WithCommand("instance start"),
+		e2e.WithArgs("--cgroup-parent", parent, c.env.ImagePath, instanceName),
+		e2e.ExpectExit(0),
+	)
+	defer c.env.RunSingularity(
+		t,
+		e2e.AsSubtest("stop"),
+		e2e.WithProfile(profile),
+		e2e.WithCommand("instance stop"),
+		e2e.WithArgs(instanceName),
+		e2e.ExpectExit(0),
+	)
 
-	args := tt.args
-	args = append(args, "-B", "/sys/fs/cgroup", c.env.ImagePath, "/bin/sh", "-c", shellCmd)
+	c.env.RunSingularity(
+		t,
+		e2e.AsSubtest("systemctl status"),
+		e2e.WithProfile(profile),
+		e2e.WithCommand("exec"),
+		e2e.WithArgs(c.env.ImagePath, "systemctl", "status", scope),
+		e2e.ExpectExit(0, e2e.ExpectOutput(e2e.ContainMatch, "active (running)")),
+	)
 
 	c.env.RunSingularity(
 		t,
+		e2e.AsSubtest("cgroup path"),
 		e2e.WithProfile(profile),
 		e2e.WithCommand("exec"),
-		e2e.WithArgs(args...),
-		e2e.ExpectExit(tt.expectErrorCode, exitFunc...),
+		e2e.WithArgs(fmt.Sprintf("instance://%s", instanceName), "cat", "/proc/self/cgroup"),
+		e2e.ExpectExit(0, e2e.ExpectOutput(e2e.ContainMatch, parent+"/"+scope)),
 	)
 }
 
-func (c *ctx) actionFlagsRoot(t *testing.T) {
-	c.actionFlags(t, e2e.RootProfile)
+func (c *ctx) cgroupParentRoot(t *testing.T) {
+	c.cgroupParent(t, e2e.RootProfile)
 }
 
-func (c *ctx) actionFlagsRootless(t *testing.T) {
-	for _, profile := range []e2e.Profile{e2e.UserProfile, e2e.UserNamespaceProfile, e2e.FakerootProfile} {
-		t.Run(profile.String(), func(t *testing.T) {
-			c.actionFlags(t, profile)
-		})
-	}
+func (c *ctx) cgroupParentRootless(t *testing.T) {
+	c.cgroupParent(t, e2e.UserProfile)
 }
 
 // E2ETests is the main func to trigger the test suite
@@ -508,11 +726,19 @@ func E2ETests(env e2e.TestEnv) testhelper.Tests {
 	np := testhelper.NoParallel
 
 	return testhelper.Tests{
-		"instance root cgroups":         np(env.WithRootManagers(c.instanceApplyRoot)),
-		"instance rootless cgroups":     np(env.WithRootlessManagers(c.instanceApplyRootless)),
-		"action root cgroups":           np(env.WithRootManagers(c.actionApplyRoot)),
-		"action rootless cgroups":       np(env.WithRootlessManagers(c.actionApplyRootless)),
-		"action flags root cgroups":     np(env.WithRootManagers(c.actionFlagsRoot)),
-		"action flags rootless cgroups": np(env.WithRootlessManagers(c.actionFlagsRootless)),
+		"instance root cgroups":             np(env.WithRootManagers(c.instanceApplyRoot)),
+		"instance rootless cgroups":         np(env.WithRootlessManagers(c.instanceApplyRootless)),
+		"action root cgroups":               np(env.WithRootManagers(c.actionApplyRoot)),
+		"action rootless cgroups":           np(env.WithRootlessManagers(c.actionApplyRootless)),
+		"action OCI root cgroups":           np(env.WithRootManagers(c.actionApplyOCIRoot)),
+		"action OCI rootless cgroups":       np(env.WithRootlessManagers(c.actionApplyOCIRootless)),
+		"action flags root cgroups":         np(env.WithRootManagers(c.actionFlagsRoot)),
+		"action flags rootless cgroups":     np(env.WithRootlessManagers(c.actionFlagsRootless)),
+		"action flags OCI root cgroups":     np(env.WithRootManagers(c.actionFlagsOCIRoot)),
+		"action flags OCI rootless cgroups": np(env.WithRootlessManagers(c.actionFlagsOCIRootless)),
+		"instance stats root":               np(env.WithRootManagers(c.instanceStatsRoot)),
+		"instance stats rootless":           np(env.WithRootlessManagers(c.instanceStatsRootless)),
+		"cgroup parent root":                np(env.WithRootManagers(c.cgroupParentRoot)),
+		"cgroup parent rootless":            np(env.WithRootlessManagers(c.cgroupParentRootless)),
 	}
-}
\ No newline at end of file
+}
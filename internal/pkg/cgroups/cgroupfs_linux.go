@@ -0,0 +1,81 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cgroups
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// writeResourceFiles writes resources' cgroupfs v2 files directly under path. Only cpu.max (when
+// both CPU quota and period are set), memory.max and pids.max are written; CPU shares, cpusets,
+// memory reservation, swap and swappiness, the OOM killer setting and block IO weight are not.
+// When delegated is
+// non-nil, a controller's file is only written if delegated[controller] is true (the rootless
+// case, where undelegated controllers are silently skipped - a warning was already logged by the
+// caller); a nil delegated map means every controller is available, which always holds for a
+// privileged caller.
+func writeResourceFiles(path string, resources *specs.LinuxResources, delegated map[string]bool) error {
+	allowed := func(controller string) bool {
+		return delegated == nil || delegated[controller]
+	}
+
+	if allowed("cpu") && resources.CPU != nil {
+		if resources.CPU.Quota != nil && resources.CPU.Period != nil {
+			v := fmt.Sprintf("%d %d", *resources.CPU.Quota, *resources.CPU.Period)
+			if err := os.WriteFile(filepath.Join(path, "cpu.max"), []byte(v), 0o644); err != nil {
+				return fmt.Errorf("while writing cpu.max: %w", err)
+			}
+		}
+	}
+	if allowed("memory") && resources.Memory != nil && resources.Memory.Limit != nil {
+		v := fmt.Sprintf("%d", *resources.Memory.Limit)
+		if err := os.WriteFile(filepath.Join(path, "memory.max"), []byte(v), 0o644); err != nil {
+			return fmt.Errorf("while writing memory.max: %w", err)
+		}
+	}
+	if allowed("pids") && resources.Pids != nil {
+		v := fmt.Sprintf("%d", resources.Pids.Limit)
+		if err := os.WriteFile(filepath.Join(path, "pids.max"), []byte(v), 0o644); err != nil {
+			return fmt.Errorf("while writing pids.max: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ApplyCgroupfsDirect creates scopeName as a v2 cgroup directly under parent (relative to
+// /sys/fs/cgroup), moves pid into it, and writes cfg's resources. It's the privileged
+// counterpart to RootlessManager.ApplyDelegated for hosts whose configured cgroup manager is
+// cgroupfs rather than systemd - root can write cgroupfs directly, so there's no need to go
+// through a DBus transient unit at all. Returns the absolute path of the cgroup created, for the
+// caller to persist (e.g. instance.File.CgroupPath).
+func ApplyCgroupfsDirect(parent, scopeName string, pid int, cfg *Config) (string, error) {
+	path := filepath.Join("/sys/fs/cgroup", parent, scopeName)
+
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return "", fmt.Errorf("while creating cgroup %s: %w", path, err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0o644); err != nil {
+		return "", fmt.Errorf("while adding pid %d to cgroup %s: %w", pid, path, err)
+	}
+
+	if cfg != nil {
+		resources, err := ToLinuxResources(cfg)
+		if err != nil {
+			return "", err
+		}
+		if err := writeResourceFiles(path, resources, nil); err != nil {
+			return "", err
+		}
+	}
+
+	return path, nil
+}
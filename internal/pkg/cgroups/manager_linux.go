@@ -0,0 +1,18 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cgroups
+
+import "os"
+
+// DetectManager reports the host's configured cgroup manager, using the same heuristic runc and
+// crun use to pick a default --systemd-cgroup value: systemd is in charge of cgroups whenever
+// it's running as pid 1's init system.
+func DetectManager() Manager {
+	if _, err := os.Stat("/run/systemd/system"); err == nil {
+		return ManagerSystemd
+	}
+	return ManagerCgroupfs
+}
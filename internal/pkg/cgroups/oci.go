@@ -0,0 +1,79 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cgroups
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// LoadLinuxResourcesJSON parses path as a JSON file matching the OCI runtime-spec
+// LinuxResources object, the same schema tools like `podman create -o json` or `crun` emit.
+// This is the --linux-resources counterpart to the runc-flavored TOML accepted by
+// --apply-cgroups, letting resource limits be shared across Singularity, crun and podman
+// without translation.
+func LoadLinuxResourcesJSON(path string) (*specs.LinuxResources, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("while reading %s: %w", path, err)
+	}
+
+	r := &specs.LinuxResources{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("while parsing %s as OCI LinuxResources: %w", path, err)
+	}
+	return r, nil
+}
+
+// ConfigFromLinuxResources builds a Config directly from an already-parsed OCI runtime-spec
+// LinuxResources block, as an alternative entry point to parsing an --apply-cgroups TOML file.
+// It's the inverse of ToLinuxResources, and is intentionally lossy in the same places
+// ToLinuxResources is: only the fields Config itself understands are populated.
+func ConfigFromLinuxResources(r *specs.LinuxResources) *Config {
+	cfg := &Config{}
+
+	if r.CPU != nil {
+		if r.CPU.Quota != nil && r.CPU.Period != nil && *r.CPU.Period > 0 {
+			cfg.CPUs = float64(*r.CPU.Quota) / float64(*r.CPU.Period)
+		}
+		if r.CPU.Shares != nil {
+			cfg.CPUShares = int64(*r.CPU.Shares)
+		}
+		cfg.CPUSetCPUs = r.CPU.Cpus
+		cfg.CPUSetMems = r.CPU.Mems
+	}
+
+	if r.Memory != nil {
+		if r.Memory.Limit != nil {
+			cfg.Memory = *r.Memory.Limit
+		}
+		if r.Memory.Reservation != nil {
+			cfg.MemoryReservation = *r.Memory.Reservation
+		}
+		if r.Memory.Swap != nil {
+			cfg.MemorySwap = *r.Memory.Swap
+		}
+		if r.Memory.Swappiness != nil {
+			cfg.MemorySwappiness = int64(*r.Memory.Swappiness)
+		}
+		if r.Memory.DisableOOMKiller != nil {
+			cfg.OOMKillDisable = *r.Memory.DisableOOMKiller
+		}
+	}
+
+	if r.BlockIO != nil && r.BlockIO.Weight != nil {
+		cfg.BlkioWeight = int64(*r.BlockIO.Weight)
+	}
+
+	if r.Pids != nil {
+		cfg.PidsLimit = r.Pids.Limit
+	}
+
+	return cfg
+}
@@ -0,0 +1,27 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cgroups
+
+import "fmt"
+
+// DefaultParent returns the systemd slice a container/instance is placed under when the caller
+// doesn't request a --cgroup-parent of their own. This is a bare slice name suitable for a
+// systemd unit's Slice= property (e.g. "app.slice"), not a cgroupfs path - the
+// user.slice/user-$UID.slice/user@$UID.service/ prefix that precedes it for rootless users is
+// implicit in which systemd manager (system vs the caller's --user instance) the scope is
+// requested from, and is added back in by cgroups.ScopePath when a full path is needed.
+func DefaultParent(uid int, privileged bool) string {
+	if privileged {
+		return "system.slice"
+	}
+	return "app.slice"
+}
+
+// ScopeName returns the systemd scope unit name a container/instance is started under, given
+// its instance name (or a generated UUID for one-shot `exec`/`run` containers).
+func ScopeName(id string) string {
+	return fmt.Sprintf("singularity-%s.scope", id)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cgroups
+
+import (
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Manager identifies the cgroup manager in use on the host, i.e. whether cgroupfs should be
+// written to directly, or whether units should be requested from systemd.
+type Manager string
+
+const (
+	// ManagerCgroupfs drives cgroups by writing cgroupfs directly.
+	ManagerCgroupfs Manager = "cgroupfs"
+	// ManagerSystemd drives cgroups via systemd transient units, required when the host's
+	// cgroup manager is systemd and cgroups v2 is in unified mode.
+	ManagerSystemd Manager = "systemd"
+)
+
+// Config is the resolved set of resource limits to apply to a container or instance, merged
+// from an --apply-cgroups TOML file (if any) and the individual resource flags, with the
+// individual flags taking precedence as they are applied after the TOML is parsed.
+type Config struct {
+	CPUs              float64
+	CPUShares         int64
+	CPUSetCPUs        string
+	CPUSetMems        string
+	Memory            int64
+	MemoryReservation int64
+	MemorySwap        int64
+	MemorySwappiness  int64
+	BlkioWeight       int64
+	PidsLimit         int64
+	OOMKillDisable    bool
+	// Parent is the slice the container/instance's scope should be created under, e.g.
+	// "system.slice" or a user-supplied "--cgroup-parent". With ManagerSystemd it names the
+	// systemd slice the scope is requested under; with ManagerCgroupfs (privileged instances,
+	// see ApplyCgroupfsDirect) it's used as a directory under /sys/fs/cgroup instead. Defaults
+	// to DefaultParent(uid, privileged) when empty.
+	Parent string
+}
+
+// ToLinuxResources translates a resolved Config into the OCI runtime-spec LinuxResources block,
+// for use by the OCI launcher when generating config.json. It mirrors the same value semantics
+// the native launcher applies when writing cgroupfs directly.
+func ToLinuxResources(cfg *Config) (*specs.LinuxResources, error) {
+	r := &specs.LinuxResources{}
+
+	if cfg.CPUs > 0 || cfg.CPUShares > 0 || cfg.CPUSetCPUs != "" || cfg.CPUSetMems != "" {
+		r.CPU = &specs.LinuxCPU{}
+		if cfg.CPUs > 0 {
+			period := uint64(100000)
+			quota := int64(cfg.CPUs * 100000)
+			r.CPU.Period = &period
+			r.CPU.Quota = &quota
+		}
+		if cfg.CPUShares > 0 {
+			shares := uint64(cfg.CPUShares)
+			r.CPU.Shares = &shares
+		}
+		r.CPU.Cpus = cfg.CPUSetCPUs
+		r.CPU.Mems = cfg.CPUSetMems
+	}
+
+	if cfg.Memory > 0 || cfg.MemoryReservation > 0 || cfg.MemorySwap > 0 || cfg.MemorySwappiness > 0 || cfg.OOMKillDisable {
+		r.Memory = &specs.LinuxMemory{}
+		if cfg.Memory > 0 {
+			r.Memory.Limit = &cfg.Memory
+		}
+		if cfg.MemoryReservation > 0 {
+			r.Memory.Reservation = &cfg.MemoryReservation
+		}
+		if cfg.MemorySwap > 0 {
+			r.Memory.Swap = &cfg.MemorySwap
+		}
+		if cfg.MemorySwappiness > 0 {
+			swappiness := uint64(cfg.MemorySwappiness)
+			r.Memory.Swappiness = &swappiness
+		}
+		r.Memory.DisableOOMKiller = &cfg.OOMKillDisable
+	}
+
+	if cfg.BlkioWeight > 0 {
+		weight := uint16(cfg.BlkioWeight)
+		r.BlockIO = &specs.LinuxBlockIO{Weight: &weight}
+	}
+
+	if cfg.PidsLimit > 0 {
+		r.Pids = &specs.LinuxPids{Limit: cfg.PidsLimit}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cgroups
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sylabs/singularity/pkg/sylog"
+)
+
+// delegatedControllersPath is where the kernel reports which controllers the system's systemd
+// instance has delegated down to the per-user manager.
+func delegatedControllersPath(uid int) string {
+	return filepath.Join("/sys/fs/cgroup/user.slice",
+		fmt.Sprintf("user-%d.slice", uid),
+		fmt.Sprintf("user@%d.service", uid),
+		"cgroup.controllers")
+}
+
+// delegatedControllers returns the set of controller names delegated to the calling user's
+// systemd-user manager, read from cgroup.controllers under their user@.service scope.
+func delegatedControllers(uid int) (map[string]bool, error) {
+	b, err := os.ReadFile(delegatedControllersPath(uid))
+	if err != nil {
+		return nil, fmt.Errorf("while reading delegated controllers: %w", err)
+	}
+
+	delegated := map[string]bool{}
+	for _, c := range strings.Fields(string(b)) {
+		delegated[c] = true
+	}
+	return delegated, nil
+}
+
+// ScopePath returns the absolute cgroupfs path of the scope AddProcess creates for parent and
+// scopeName under the given user's systemd-user manager. Callers that need to record where an
+// instance's cgroup ended up (e.g. to persist it to instance.File.CgroupPath) should use this
+// rather than reconstructing the path themselves.
+func ScopePath(uid int, parent, scopeName string) string {
+	return filepath.Join("/sys/fs/cgroup/user.slice",
+		fmt.Sprintf("user-%d.slice", uid),
+		fmt.Sprintf("user@%d.service", uid),
+		parent, scopeName)
+}
+
+// RootlessManager drives cgroups for an unprivileged process via the caller's per-user systemd
+// instance, rather than writing cgroupfs directly (which an unprivileged user cannot do outside
+// of the slice/scope systemd has already delegated to them).
+type RootlessManager struct {
+	uid  int
+	conn *dbus.Conn
+}
+
+// NewRootlessManager connects to the user's systemd-user DBus socket at
+// $XDG_RUNTIME_DIR/systemd/private.
+func NewRootlessManager(uid int) (*RootlessManager, error) {
+	conn, err := dbus.NewUserConnectionContext(nil)
+	if err != nil {
+		return nil, fmt.Errorf("while connecting to systemd-user dbus: %w", err)
+	}
+	return &RootlessManager{uid: uid, conn: conn}, nil
+}
+
+// Close releases the DBus connection.
+func (m *RootlessManager) Close() {
+	m.conn.Close()
+}
+
+// AddProcess requests a transient scope under parent (e.g. "app.slice", or a user-supplied
+// --cgroup-parent) via StartTransientUnit, and places pid into it. scopeName should be unique,
+// e.g. "singularity-<instance-or-uuid>.scope" (see ScopeName).
+func (m *RootlessManager) AddProcess(parent, scopeName string, pid int) error {
+	props := []dbus.Property{
+		dbus.PropPids(uint32(pid)),
+		dbus.PropSlice(parent),
+	}
+
+	_, err := m.conn.StartTransientUnitContext(nil, scopeName, "fail", props, nil)
+	if err != nil {
+		return fmt.Errorf("while starting transient scope %s: %w", scopeName, err)
+	}
+	return nil
+}
+
+// ApplyDelegated applies cfg to parent/scopeName, skipping any resource whose controller has not
+// been delegated to this user rather than erroring, since the kernel will refuse to let us write
+// those cgroupfs files at all. Each skipped controller is logged as a warning so the user
+// understands why a requested limit didn't take effect.
+func (m *RootlessManager) ApplyDelegated(parent, scopeName string, cfg *Config) error {
+	delegated, err := delegatedControllers(m.uid)
+	if err != nil {
+		return err
+	}
+
+	if !delegated["cpu"] && (cfg.CPUs > 0 || cfg.CPUShares > 0) {
+		sylog.Warningf("cpu controller is not delegated to this user - cpu limits will not be applied")
+	}
+	if !delegated["memory"] && (cfg.Memory > 0 || cfg.MemoryReservation > 0 || cfg.MemorySwap > 0) {
+		sylog.Warningf("memory controller is not delegated to this user - memory limits will not be applied")
+	}
+	if !delegated["pids"] && cfg.PidsLimit > 0 {
+		sylog.Warningf("pids controller is not delegated to this user - pids-limit will not be applied")
+	}
+	// devices (and on some distros blkio) are very commonly not delegated, since allowing an
+	// unprivileged user to program them would let them escape container device isolation.
+	if !delegated["devices"] {
+		sylog.Warningf("devices controller is not delegated to this user - device limits will not be applied")
+	}
+	if !delegated["io"] && !delegated["blkio"] && cfg.BlkioWeight > 0 {
+		sylog.Warningf("blkio controller is not delegated to this user - blkio-weight will not be applied")
+	}
+
+	resources, err := ToLinuxResources(cfg)
+	if err != nil {
+		return err
+	}
+	return m.applyResourcesToScope(parent, scopeName, resources, delegated)
+}
+
+// applyResourcesToScope writes the cgroupfs files for each delegated controller present in
+// resources, under the scope previously created by AddProcess. Controllers absent from
+// delegated are skipped (a warning was already logged by the caller).
+func (m *RootlessManager) applyResourcesToScope(parent, scopeName string, resources *specs.LinuxResources, delegated map[string]bool) error {
+	return writeResourceFiles(ScopePath(m.uid, parent, scopeName), resources, delegated)
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cgroups
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	runccgroups "github.com/opencontainers/runc/libcontainer/cgroups"
+)
+
+// Stats is the live resource usage accounting read from a running container or instance's
+// cgroup, in the same shape regardless of whether the host is on cgroups v1 or v2.
+type Stats struct {
+	CPUUsageUsec uint64
+	MemCurrent   uint64
+	MemPeak      uint64
+	MemLimit     uint64
+	PidsCurrent  uint64
+	PidsMax      uint64
+	IOReadBytes  uint64
+	IOWriteBytes uint64
+}
+
+// PathForPID discovers the absolute cgroupfs path(s) for pid, by reading /proc/<pid>/cgroup. On
+// v2 this is the single unified path, returned under the "" key. On v1, cpu/cpuacct, memory and
+// pids are separate mount hierarchies, so each controller's mount point is returned under its
+// own key (e.g. "cpuacct", "memory", "pids") - callers reading v1 stats must look up the path
+// for the specific controller they want, not assume a single path serves every file.
+func PathForPID(pid int) (map[string]string, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return nil, fmt.Errorf("while opening cgroup info for pid %d: %w", pid, err)
+	}
+	defer f.Close()
+
+	paths := map[string]string{}
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.SplitN(s.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			paths[""] = filepath.Join("/sys/fs/cgroup", fields[2])
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller == "" {
+				continue
+			}
+			paths[controller] = filepath.Join("/sys/fs/cgroup", controller, fields[2])
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no cgroup entries found for pid %d", pid)
+	}
+	return paths, nil
+}
+
+// ReadStats reads usage accounting from paths, as returned by PathForPID (or an equivalent
+// single-entry map{"": path} built from a recorded v2 cgroup path, e.g.
+// instance.File.CgroupPath).
+func ReadStats(paths map[string]string) (*Stats, error) {
+	if runccgroups.IsCgroup2UnifiedMode() {
+		return readStatsV2(paths[""])
+	}
+	return readStatsV1(paths)
+}
+
+func readKeyValueFile(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	kv := map[string]uint64{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		kv[fields[0]] = v
+	}
+	return kv, s.Err()
+}
+
+func readUint(path string) (uint64, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("while parsing %s: %w", path, err)
+	}
+	return v, nil
+}
+
+func readStatsV2(path string) (*Stats, error) {
+	stats := &Stats{}
+
+	if cpuStat, err := readKeyValueFile(filepath.Join(path, "cpu.stat")); err == nil {
+		stats.CPUUsageUsec = cpuStat["usage_usec"]
+	}
+	if v, err := readUint(filepath.Join(path, "memory.current")); err == nil {
+		stats.MemCurrent = v
+	}
+	if v, err := readUint(filepath.Join(path, "memory.peak")); err == nil {
+		stats.MemPeak = v
+	}
+	if v, err := readUint(filepath.Join(path, "memory.max")); err == nil {
+		stats.MemLimit = v
+	}
+	if v, err := readUint(filepath.Join(path, "pids.current")); err == nil {
+		stats.PidsCurrent = v
+	}
+	if v, err := readUint(filepath.Join(path, "pids.max")); err == nil {
+		stats.PidsMax = v
+	}
+	if b, err := os.ReadFile(filepath.Join(path, "io.stat")); err == nil {
+		// io.stat has one line per device, "MAJ:MIN rbytes=N wbytes=N ..."; sum over devices.
+		for _, field := range strings.Fields(string(b)) {
+			if kv := strings.SplitN(field, "=", 2); len(kv) == 2 {
+				v, _ := strconv.ParseUint(kv[1], 10, 64)
+				switch kv[0] {
+				case "rbytes":
+					stats.IOReadBytes += v
+				case "wbytes":
+					stats.IOWriteBytes += v
+				}
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+func readStatsV1(paths map[string]string) (*Stats, error) {
+	stats := &Stats{}
+
+	cpuPath := paths["cpuacct"]
+	if cpuPath == "" {
+		cpuPath = paths["cpu"]
+	}
+	if cpuPath != "" {
+		if cpuStat, err := readKeyValueFile(filepath.Join(cpuPath, "cpuacct.stat")); err == nil {
+			stats.CPUUsageUsec = (cpuStat["user"] + cpuStat["system"]) * 10000
+		}
+	}
+
+	if memPath := paths["memory"]; memPath != "" {
+		if v, err := readUint(filepath.Join(memPath, "memory.usage_in_bytes")); err == nil {
+			stats.MemCurrent = v
+		}
+		if v, err := readUint(filepath.Join(memPath, "memory.max_usage_in_bytes")); err == nil {
+			stats.MemPeak = v
+		}
+		if v, err := readUint(filepath.Join(memPath, "memory.limit_in_bytes")); err == nil {
+			stats.MemLimit = v
+		}
+	}
+
+	if pidsPath := paths["pids"]; pidsPath != "" {
+		if v, err := readUint(filepath.Join(pidsPath, "pids.current")); err == nil {
+			stats.PidsCurrent = v
+		}
+		if v, err := readUint(filepath.Join(pidsPath, "pids.max")); err == nil {
+			stats.PidsMax = v
+		}
+	}
+
+	return stats, nil
+}
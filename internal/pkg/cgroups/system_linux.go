@@ -0,0 +1,69 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cgroups
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+)
+
+// SystemManager drives cgroups for a privileged process via the host's system DBus, the
+// privileged counterpart to RootlessManager: it requests transient scopes from systemd's system
+// instance rather than a per-user one. Since every controller is available to root, unlike
+// RootlessManager.ApplyDelegated it never needs to check what's been delegated - it always
+// writes every resource writeResourceFiles handles.
+type SystemManager struct {
+	conn *dbus.Conn
+}
+
+// NewSystemManager connects to the system DBus socket.
+func NewSystemManager() (*SystemManager, error) {
+	conn, err := dbus.NewSystemConnectionContext(nil)
+	if err != nil {
+		return nil, fmt.Errorf("while connecting to systemd system dbus: %w", err)
+	}
+	return &SystemManager{conn: conn}, nil
+}
+
+// Close releases the DBus connection.
+func (m *SystemManager) Close() {
+	m.conn.Close()
+}
+
+// AddProcess requests a transient scope under parent via StartTransientUnit, and places pid into
+// it, the same way RootlessManager.AddProcess does for a user's systemd-user manager.
+func (m *SystemManager) AddProcess(parent, scopeName string, pid int) error {
+	props := []dbus.Property{
+		dbus.PropPids(uint32(pid)),
+		dbus.PropSlice(parent),
+	}
+
+	_, err := m.conn.StartTransientUnitContext(nil, scopeName, "fail", props, nil)
+	if err != nil {
+		return fmt.Errorf("while starting transient scope %s: %w", scopeName, err)
+	}
+	return nil
+}
+
+// Apply writes cfg's resources to parent/scopeName, unconditionally - every controller is
+// available to a privileged caller, unlike RootlessManager.ApplyDelegated.
+func (m *SystemManager) Apply(parent, scopeName string, cfg *Config) error {
+	resources, err := ToLinuxResources(cfg)
+	if err != nil {
+		return err
+	}
+	return writeResourceFiles(SystemScopePath(parent, scopeName), resources, nil)
+}
+
+// SystemScopePath returns the absolute cgroupfs path of the scope AddProcess creates for parent
+// and scopeName. Unlike RootlessManager's ScopePath there's no user.slice/user-$UID.slice/
+// user@$UID.service/ prefix - a privileged scope is requested directly under the real top-level
+// hierarchy.
+func SystemScopePath(parent, scopeName string) string {
+	return filepath.Join("/sys/fs/cgroup", parent, scopeName)
+}
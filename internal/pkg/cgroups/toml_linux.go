@@ -0,0 +1,60 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package cgroups
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlConfig is the on-disk shape of an --apply-cgroups file, a runc-flavored TOML resource
+// description. It's parsed into a Config rather than used directly, so both --apply-cgroups and
+// --linux-resources (see oci.go) funnel into the same resolved type.
+type tomlConfig struct {
+	CPUs              float64 `toml:"cpus"`
+	CPUShares         int64   `toml:"cpu_shares"`
+	CPUSetCPUs        string  `toml:"cpuset_cpus"`
+	CPUSetMems        string  `toml:"cpuset_mems"`
+	Memory            int64   `toml:"memory"`
+	MemoryReservation int64   `toml:"memory_reservation"`
+	MemorySwap        int64   `toml:"memory_swap"`
+	MemorySwappiness  int64   `toml:"memory_swappiness"`
+	BlkioWeight       int64   `toml:"blkio_weight"`
+	PidsLimit         int64   `toml:"pids_limit"`
+	OOMKillDisable    bool    `toml:"oom_kill_disable"`
+}
+
+// LoadConfigTOML parses path as an --apply-cgroups TOML file into a Config. This is the
+// TOML counterpart to LoadLinuxResourcesJSON/ConfigFromLinuxResources - the two ways a resolved
+// Config can be seeded from a file, before the individual --cpus/--memory/... flags are layered
+// on top by the caller.
+func LoadConfigTOML(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("while reading %s: %w", path, err)
+	}
+
+	tc := tomlConfig{}
+	if _, err := toml.Decode(string(b), &tc); err != nil {
+		return nil, fmt.Errorf("parsing error in %s: %w", path, err)
+	}
+
+	return &Config{
+		CPUs:              tc.CPUs,
+		CPUShares:         tc.CPUShares,
+		CPUSetCPUs:        tc.CPUSetCPUs,
+		CPUSetMems:        tc.CPUSetMems,
+		Memory:            tc.Memory,
+		MemoryReservation: tc.MemoryReservation,
+		MemorySwap:        tc.MemorySwap,
+		MemorySwappiness:  tc.MemorySwappiness,
+		BlkioWeight:       tc.BlkioWeight,
+		PidsLimit:         tc.PidsLimit,
+		OOMKillDisable:    tc.OOMKillDisable,
+	}, nil
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// SingSubDir is the subdirectory instance state files for `singularity` (as opposed to
+// `apptainer`-compat installs) are stored under.
+const SingSubDir = "singularity"
+
+// File describes the on-disk state of a running instance, as created by `instance start` and
+// read back by `instance list`/`instance stop`/`instance stats` and the `instance://` join path.
+type File struct {
+	Pid        int    `json:"pid"`
+	PPid       int    `json:"ppid"`
+	Name       string `json:"name"`
+	User       string `json:"user"`
+	Image      string `json:"image"`
+	LogErrPath string `json:"logErrPath"`
+	LogOutPath string `json:"logOutPath"`
+	// CgroupPath is the absolute cgroupfs path of the cgroup the launcher placed this
+	// instance's process tree under, if any (empty when the instance was started without
+	// cgroups applied). It's always a single unified-hierarchy (v2) path; no per-controller
+	// v1 paths are recorded, so on a v1 host `instance stats` reads no accounting files from
+	// it and reports zeros. It's read back by `instance stats` to locate the accounting files
+	// to report on.
+	CgroupPath string `json:"cgroupPath,omitempty"`
+
+	Config []byte `json:"config"`
+
+	path string
+}
+
+func dir(subDir string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("while determining home directory: %w", err)
+	}
+	return filepath.Join(home, ".singularity", "instances", "app", subDir), nil
+}
+
+// Get looks up the named instance's state file under subDir (e.g. SingSubDir), taking a shared
+// flock for the duration of the read so it can't observe a Put from another process half-written.
+func Get(name, subDir string) (*File, error) {
+	d, err := dir(subDir)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(d, name, fmt.Sprintf("%s.json", name))
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("no instance found with name %s: %w", name, err)
+	}
+	defer fh.Close()
+
+	if err := unix.Flock(int(fh.Fd()), unix.LOCK_SH); err != nil {
+		return nil, fmt.Errorf("while locking instance state for %s: %w", name, err)
+	}
+	defer unix.Flock(int(fh.Fd()), unix.LOCK_UN)
+
+	b, err := io.ReadAll(fh)
+	if err != nil {
+		return nil, fmt.Errorf("while reading instance state for %s: %w", name, err)
+	}
+
+	f := &File{path: path}
+	if err := json.Unmarshal(b, f); err != nil {
+		return nil, fmt.Errorf("while parsing instance state for %s: %w", name, err)
+	}
+	return f, nil
+}
+
+// Put persists f back to the state file it was loaded from (or created for, via Create), taking
+// an exclusive flock for the duration of the write so a concurrent Get/Put (e.g. `instance start`
+// recording a cgroup path while `instance stats`/`instance list` reads it back) can't observe a
+// partial write. This is cooperative advisory locking - every reader/writer of the file needs to
+// go through Get/Put to benefit from it.
+func (f *File) Put() error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("while marshalling instance state: %w", err)
+	}
+
+	fh, err := os.OpenFile(f.path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("while opening instance state for %s: %w", f.Name, err)
+	}
+	defer fh.Close()
+
+	if err := unix.Flock(int(fh.Fd()), unix.LOCK_EX); err != nil {
+		return fmt.Errorf("while locking instance state for %s: %w", f.Name, err)
+	}
+	defer unix.Flock(int(fh.Fd()), unix.LOCK_UN)
+
+	if err := fh.Truncate(0); err != nil {
+		return fmt.Errorf("while truncating instance state for %s: %w", f.Name, err)
+	}
+	if _, err := fh.WriteAt(b, 0); err != nil {
+		return fmt.Errorf("while writing instance state for %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// Create starts a new instance state file for name under subDir.
+func Create(name, subDir string) (*File, error) {
+	d, err := dir(subDir)
+	if err != nil {
+		return nil, err
+	}
+
+	instDir := filepath.Join(d, name)
+	if err := os.MkdirAll(instDir, 0o700); err != nil {
+		return nil, fmt.Errorf("while creating instance directory: %w", err)
+	}
+
+	return &File{
+		Name: name,
+		path: filepath.Join(instDir, fmt.Sprintf("%s.json", name)),
+	}, nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package native
+
+import (
+	"fmt"
+
+	"github.com/sylabs/singularity/internal/pkg/cgroups"
+	"github.com/sylabs/singularity/internal/pkg/instance"
+	"github.com/sylabs/singularity/pkg/sylog"
+)
+
+// ApplyInstanceCgroupsRootless is called by `instance start` once the instance's process (pid)
+// exists, when running unprivileged. It requests a transient systemd-user scope for pid under
+// cfg's configured (or default) parent slice, programs whichever controllers are delegated to
+// this user, and records the resulting cgroup path on file so `instance stats` can find it
+// later. cfg may be nil, in which case only the scope itself is created (to group the instance
+// under systemd) with no resource limits applied.
+func ApplyInstanceCgroupsRootless(file *instance.File, uid int, pid int, cfg *cgroups.Config) error {
+	parent := ""
+	if cfg != nil {
+		parent = cfg.Parent
+	}
+	if parent == "" {
+		parent = cgroups.DefaultParent(uid, false)
+	}
+	scope := cgroups.ScopeName(file.Name)
+
+	rm, err := cgroups.NewRootlessManager(uid)
+	if err != nil {
+		return fmt.Errorf("while setting up rootless cgroups manager: %w", err)
+	}
+	defer rm.Close()
+
+	if err := rm.AddProcess(parent, scope, pid); err != nil {
+		return fmt.Errorf("while creating cgroup scope for instance %s: %w", file.Name, err)
+	}
+
+	if cfg != nil {
+		if err := rm.ApplyDelegated(parent, scope, cfg); err != nil {
+			return fmt.Errorf("while applying cgroups limits to instance %s: %w", file.Name, err)
+		}
+	}
+
+	file.CgroupPath = cgroups.ScopePath(uid, parent, scope)
+	if err := file.Put(); err != nil {
+		return fmt.Errorf("while persisting cgroup path for instance %s: %w", file.Name, err)
+	}
+
+	sylog.Debugf("instance %s placed in rootless cgroup scope %s", file.Name, file.CgroupPath)
+	return nil
+}
+
+// ApplyInstanceCgroupsPrivileged is called by `instance start` once the instance's process
+// (pid) exists, when running privileged. Root can program cgroups directly rather than going
+// through a user's systemd-user manager, so this either writes cgroupfs under the scope itself
+// (ManagerCgroupfs) or requests a transient scope from the system bus (ManagerSystemd) depending
+// on the host's configured cgroup manager. Either way it records the resulting cgroup path on
+// file so `instance stats` can find it later. cfg may be nil, in which case only the scope
+// itself is created (to group the instance, under systemd) with no resource limits applied.
+func ApplyInstanceCgroupsPrivileged(file *instance.File, pid int, cfg *cgroups.Config) error {
+	parent := ""
+	if cfg != nil {
+		parent = cfg.Parent
+	}
+	if parent == "" {
+		parent = cgroups.DefaultParent(0, true)
+	}
+	scope := cgroups.ScopeName(file.Name)
+
+	if cgroups.DetectManager() == cgroups.ManagerSystemd {
+		sm, err := cgroups.NewSystemManager()
+		if err != nil {
+			return fmt.Errorf("while setting up system cgroups manager: %w", err)
+		}
+		defer sm.Close()
+
+		if err := sm.AddProcess(parent, scope, pid); err != nil {
+			return fmt.Errorf("while creating cgroup scope for instance %s: %w", file.Name, err)
+		}
+		if cfg != nil {
+			if err := sm.Apply(parent, scope, cfg); err != nil {
+				return fmt.Errorf("while applying cgroups limits to instance %s: %w", file.Name, err)
+			}
+		}
+		file.CgroupPath = cgroups.SystemScopePath(parent, scope)
+	} else {
+		path, err := cgroups.ApplyCgroupfsDirect(parent, scope, pid, cfg)
+		if err != nil {
+			return fmt.Errorf("while placing instance %s in a cgroup: %w", file.Name, err)
+		}
+		file.CgroupPath = path
+	}
+
+	if err := file.Put(); err != nil {
+		return fmt.Errorf("while persisting cgroup path for instance %s: %w", file.Name, err)
+	}
+
+	sylog.Debugf("instance %s placed in privileged cgroup scope %s", file.Name, file.CgroupPath)
+	return nil
+}
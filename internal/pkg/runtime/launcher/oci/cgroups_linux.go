@@ -0,0 +1,49 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package oci
+
+import (
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sylabs/singularity/internal/pkg/cgroups"
+)
+
+// addCgroupsResources translates the launcher's resolved cgroups config (assembled from
+// --apply-cgroups and/or the individual --cpus/--memory/... flags, exactly as the native
+// launcher does) into the LinuxResources block of the OCI runtime-spec config.json. This is
+// what makes `--oci` honor the same resource limits as the native launcher.
+func addCgroupsResources(spec *specs.Spec, cfg *cgroups.Config) error {
+	if cfg == nil {
+		return nil
+	}
+
+	resources, err := cgroups.ToLinuxResources(cfg)
+	if err != nil {
+		return err
+	}
+
+	if spec.Linux == nil {
+		spec.Linux = &specs.Linux{}
+	}
+	spec.Linux.Resources = resources
+
+	return nil
+}
+
+// useSystemdCgroups reports whether the runtime (runc/crun) should be invoked with
+// --systemd-cgroup, i.e. whether the host's configured cgroup manager is systemd rather than
+// the raw cgroupfs driver.
+func useSystemdCgroups(manager cgroups.Manager) bool {
+	return manager == cgroups.ManagerSystemd
+}
+
+// runtimeArgs returns the extra arguments that must be passed to the runc/crun invocation to
+// honor the launcher's cgroup manager choice.
+func runtimeArgs(manager cgroups.Manager) []string {
+	if useSystemdCgroups(manager) {
+		return []string{"--systemd-cgroup"}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2022, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package oci
+
+import (
+	"fmt"
+	"os/exec"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sylabs/singularity/internal/pkg/cgroups"
+)
+
+// Launcher drives an OCI-mode `exec`/`run`/`shell` through runc/crun, for the `--oci` flag on
+// the action commands. It's the counterpart to the native launcher, which programs cgroupfs
+// directly instead of generating an OCI runtime bundle.
+type Launcher struct {
+	// RuntimePath is the resolved path to the configured OCI low-level runtime (runc or crun).
+	RuntimePath string
+	// CgroupsConfig is the resolved cgroups config for this invocation - merged from
+	// --apply-cgroups/--linux-resources and/or the individual resource flags. prepareOCILauncher
+	// always sets it, to an empty Config when none of those were given; a nil value makes
+	// CreateSpec leave the spec unchanged.
+	CgroupsConfig *cgroups.Config
+	// CgroupsManager is the host's configured cgroup manager, used to decide whether to pass
+	// --systemd-cgroup to the runtime.
+	CgroupsManager cgroups.Manager
+}
+
+// CreateSpec builds the OCI runtime-spec config.json for this invocation, folding in any
+// cgroups resource limits requested for the launch.
+func (l *Launcher) CreateSpec(spec *specs.Spec) (*specs.Spec, error) {
+	if err := addCgroupsResources(spec, l.CgroupsConfig); err != nil {
+		return nil, fmt.Errorf("while applying cgroups resources to OCI spec: %w", err)
+	}
+	return spec, nil
+}
+
+// RuntimeCommand returns the runc/crun invocation for subcommand (e.g. "create", "run") against
+// bundleDir, with --systemd-cgroup appended when the host's cgroup manager is systemd.
+func (l *Launcher) RuntimeCommand(subcommand, containerID, bundleDir string) *exec.Cmd {
+	args := runtimeArgs(l.CgroupsManager)
+	args = append(args, subcommand, "--bundle", bundleDir, containerID)
+	return exec.Command(l.RuntimePath, args...)
+}